@@ -15,87 +15,216 @@
 package aggregation
 
 import (
+	"bytes"
+	encbinary "encoding/binary"
 	"fmt"
-	"github.com/dolthub/go-mysql-server/sql"
-	"github.com/dolthub/go-mysql-server/sql/expression"
-	"sort"
 	"strings"
+	"unicode/utf8"
+
+	"github.com/dolthub/vitess/go/sqltypes"
+
+	"github.com/dolthub/go-mysql-server/sql"
 )
 
+// defaultGroupConcatMemLimit is the number of rows GroupConcat will hold onto in memory (per group) before it
+// starts spilling the rest of the group to disk. Spilling only happens with an ORDER BY; without one, rows
+// are appended in memory regardless of this limit. It is used when the group_concat_mem_limit session
+// variable has not been set.
+const defaultGroupConcatMemLimit int64 = 1024
+
 type GroupConcat struct {
 	distinct string
 	sf  sql.SortFields
 	separator string
 	selectExprs []sql.Expression
+	// collation is inherited from the first select expression's type, so that ORDER BY / HAVING over the
+	// result compares using the same collation the grouped values would have.
+	collation sql.CollationID
+	// binary is true when any select expression is a binary/blob type, in which case the result is built
+	// and returned as raw bytes (LongBlob) rather than as a collated string, so bytes like 0x00 are
+	// preserved instead of being treated as text.
+	binary bool
 }
 
 var _ sql.FunctionExpression = &GroupConcat{}
 var _ sql.Aggregation = &GroupConcat{}
+var _ sql.CollationCoercible = &GroupConcat{}
 
 func NewEmptyGroupConcat() sql.Expression {
 	return &GroupConcat{}
 }
 
 func NewGroupConcat(distinct string, orderBy sql.SortFields, separator string, selectExprs []sql.Expression) (*GroupConcat, error) {
-	return &GroupConcat{distinct: distinct, sf: orderBy, separator: separator, selectExprs: selectExprs}, nil
+	var collation sql.CollationID
+	var binary bool
+	for i, se := range selectExprs {
+		t := se.Type()
+		// Any binary/blob argument forces the whole concatenation into binary mode, the same way MySQL
+		// promotes a CONCAT() of mixed text and binary arguments to binary.
+		if isBinaryType(t) {
+			binary = true
+		}
+		if i == 0 {
+			if st, ok := t.(sql.StringType); ok {
+				collation = st.Collation()
+			}
+		}
+	}
+
+	return &GroupConcat{
+		distinct:    distinct,
+		sf:          orderBy,
+		separator:   separator,
+		selectExprs: selectExprs,
+		collation:   collation,
+		binary:      binary,
+	}, nil
+}
+
+// isBinaryType reports whether t is one of the BLOB/BINARY family of types, whose values GroupConcat must
+// treat as opaque bytes rather than as collated text. The BLOB family has no length parameter, so comparing
+// against the package's fixed singletons is enough; BINARY/VARBINARY are length-parameterized (e.g.
+// VARBINARY(20)), so a column's actual type is never == to the default-length sql.Binary/sql.VarBinary
+// singletons - those two are instead recognized by their underlying wire type, which doesn't vary with
+// length.
+func isBinaryType(t sql.Type) bool {
+	switch t {
+	case sql.Blob, sql.TinyBlob, sql.MediumBlob, sql.LongBlob:
+		return true
+	}
+
+	switch t.Type() {
+	case sqltypes.Binary, sqltypes.VarBinary:
+		return true
+	default:
+		return false
+	}
+}
+
+// groupConcatBuffer wraps the shared listAggBuffer with the bookkeeping specific to group_concat_max_len
+// truncation: the running length of the concatenation built so far, and whether it has already exceeded
+// group_concat_max_len and can stop growing (when there's no ORDER BY to reconsider).
+type groupConcatBuffer struct {
+	*listAggBuffer
+	// length is the running total length of the concatenated result (including separators), used to
+	// short-circuit Update once it can no longer affect the group_concat_max_len-truncated output.
+	length int64
+	// truncated is set once length has exceeded group_concat_max_len and there is no ORDER BY, so that
+	// subsequent Update calls can be skipped entirely.
+	truncated bool
 }
 
 // NewBuffer creates a new buffer for the aggregation.
 func (g *GroupConcat) NewBuffer() sql.Row {
-	var rows []sql.Row
-	var distinctSet = make(map[string]bool)
-
-	return sql.NewRow(rows, distinctSet)
+	return sql.NewRow(&groupConcatBuffer{listAggBuffer: newListAggBuffer()})
 }
 
 // Update implements the Aggregation interface.
 func (g *GroupConcat) Update(ctx *sql.Context, buffer, originalRow sql.Row) error {
-	evalRow, err := evalExprs(ctx, g.selectExprs, originalRow)
-	if err != nil {
-		return err
-	}
+	buf := buffer[0].(*groupConcatBuffer)
 
-	// Skip if this is a null row
-	if evalRow == nil {
+	if buf.truncated {
 		return nil
 	}
 
-	// Get the current value as a string
-	v, err := sql.LongText.Convert(evalRow[0])
+	evalRow, err := evalExprs(ctx, g.selectExprs, originalRow)
 	if err != nil {
 		return err
 	}
 
-	if v == nil {
+	// Skip if this is a null row
+	if evalRow == nil {
 		return nil
 	}
 
-	vs := v.(string)
-
-	// Get the current array of rows and the map
-	rows := buffer[0].([]sql.Row)
-	distinctSet := buffer[1].(map[string]bool)
+	// Mirror MySQL's CONCAT() semantics: convert each expression to bytes and skip the whole row (rather
+	// than just the null expression) if any of them evaluate to NULL. Binary expressions are converted via
+	// LongBlob rather than LongText so that bytes like 0x00 survive instead of being mangled as text.
+	elems := make([][]byte, len(evalRow))
+	for i, v := range evalRow {
+		b, err := g.convertElem(v)
+		if err != nil {
+			return err
+		}
+		if b == nil {
+			return nil
+		}
+		elems[i] = b
+	}
+	vs := bytes.Join(elems, nil)
 
-	// Check if distinct is active if so look at and update our map
+	// Check if distinct is active if so look at and update our map. The distinct key is the whole tuple
+	// of evaluated expressions, not just the concatenated string, so e.g. group_concat(distinct a, b)
+	// treats ("a", "bc") and ("ab", "c") as different groups even though they concatenate the same.
 	if g.distinct != "" {
-		// If this value exists go ahead and return nil
-		if _, ok := distinctSet[vs]; ok {
+		distinctKey := distinctTupleKey(elems)
+		if _, ok := buf.distinctSet[distinctKey]; ok {
 			return nil
-		} else {
-			distinctSet[vs] = true
 		}
+		buf.distinctSet[distinctKey] = true
+	}
+
+	// Track the length (in bytes, matching MySQL's group_concat_max_len semantics) the final concatenation
+	// would have if we stopped here, so that once it exceeds group_concat_max_len we know nothing appended
+	// after this point can change the (truncated) result.
+	if len(buf.rows) > 0 {
+		buf.length += int64(len(g.separator))
 	}
+	buf.length += int64(len(vs))
 
 	// Append the current value to the end of the row. We want to preserve the row's original structure for
-	// for sort ordering in the final step.
-	rows = append(rows, append(originalRow, nil, vs))
+	// for sort ordering in the final step. The row that pushes buf.length past group_concat_max_len is kept
+	// here - Eval's own byte-accurate truncation is what actually cuts the result at the exact limit - only
+	// rows *after* that point are skipped below, since with no ORDER BY they can't affect the
+	// already-truncated output.
+	row := append(originalRow, nil, vs)
+	if err := buf.appendOrSpill(ctx, g.sf, getGroupConcatMemLimit(ctx), row); err != nil {
+		return err
+	}
 
-	buffer[0] = rows
-	buffer[1] = distinctSet
+	if g.sf == nil && buf.length > getGroupConcatMaxLen(ctx) {
+		// With no ORDER BY, row order in the result is arrival order, so once we've already accumulated
+		// enough to satisfy group_concat_max_len there is no point holding onto any more rows.
+		buf.truncated = true
+	}
 
 	return nil
 }
 
+// distinctTupleKey builds an unambiguous composite map key for a DISTINCT tuple by length-prefixing each
+// element instead of joining them with a separator byte - a plain separator can't distinguish, say,
+// ("a\x00b", "c") from ("a", "b\x00c") once an element is allowed to contain arbitrary bytes, which
+// binary/blob arguments make routine.
+func distinctTupleKey(elems [][]byte) string {
+	var b bytes.Buffer
+	var lenBuf [8]byte
+	for _, e := range elems {
+		encbinary.BigEndian.PutUint64(lenBuf[:], uint64(len(e)))
+		b.Write(lenBuf[:])
+		b.Write(e)
+	}
+	return b.String()
+}
+
+// convertElem converts v according to the aggregation's mode: to raw bytes via LongBlob when it is
+// operating in binary mode (any argument is binary), or to UTF-8 text via LongText otherwise. It returns a
+// nil slice (with no error) when v is SQL NULL.
+func (g *GroupConcat) convertElem(v interface{}) ([]byte, error) {
+	if g.binary {
+		b, err := sql.LongBlob.Convert(v)
+		if err != nil || b == nil {
+			return nil, err
+		}
+		return b.([]byte), nil
+	}
+
+	s, err := sql.LongText.Convert(v)
+	if err != nil || s == nil {
+		return nil, err
+	}
+	return []byte(s.(string)), nil
+}
+
 // Merge implements the Aggregation interface.
 func (g *GroupConcat) Merge(ctx *sql.Context, buffer, partial sql.Row) error {
 	return g.Update(ctx, buffer, partial)
@@ -103,43 +232,54 @@ func (g *GroupConcat) Merge(ctx *sql.Context, buffer, partial sql.Row) error {
 
 // cc: https://dev.mysql.com/doc/refman/8.0/en/aggregate-functions.html#function_group-concat
 func (g *GroupConcat) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
-	rows := row[0].([]sql.Row)
-	
-	if len(rows) == 0 {
-		return nil, nil
-	}
+	buf := row[0].(*groupConcatBuffer)
 
-	// Execute the order operation if it exists.
-	if g.sf != nil {
-		sorter := &expression.Sorter{
-			SortFields: g.sf,
-			Rows: rows,
-			Ctx: ctx,
-		}
+	rows, err := buf.sortedRows(ctx, g.sf)
+	if err != nil {
+		return nil, err
+	}
+	defer buf.close()
 
-		sort.Stable(sorter)
-		if sorter.LastError != nil {
-			return nil, sorter.LastError
-		}
+	if len(rows) == 0 {
+		return nil, nil
 	}
 
-	ret := ""
+	var b bytes.Buffer
 	for i, row := range rows {
 		lastIdx := len(row) - 1
-		if i == len(rows) - 1 {
-			ret += row[lastIdx].(string)
-		} else {
-			ret += row[lastIdx].(string) + g.separator
+		b.Write(row[lastIdx].([]byte))
+		if i != len(rows)-1 {
+			b.WriteString(g.separator)
 		}
 	}
 
+	// MySQL measures group_concat_max_len in bytes, not characters, so compare and truncate on bytes here.
 	maxLen := getGroupConcatMaxLen(ctx)
-
+	ret := b.Bytes()
 	if int64(len(ret)) > maxLen {
-		ret = ret[0:maxLen]
+		if g.binary {
+			// Binary output has no notion of a "character", so truncate on the raw byte boundary.
+			ret = ret[:maxLen]
+		} else {
+			// Text output must not be cut in the middle of a multi-byte UTF-8 rune.
+			ret = truncateOnRuneBoundary(ret, maxLen)
+		}
+	}
+
+	if g.binary {
+		return ret, nil
 	}
+	return string(ret), nil
+}
 
-	return ret, nil
+// truncateOnRuneBoundary returns the longest prefix of b no longer than maxLen bytes that ends on a valid
+// UTF-8 rune boundary.
+func truncateOnRuneBoundary(b []byte, maxLen int64) []byte {
+	idx := int(maxLen)
+	for idx > 0 && !utf8.RuneStart(b[idx]) {
+		idx--
+	}
+	return b[:idx]
 }
 
 func getGroupConcatMaxLen(ctx *sql.Context) int64 {
@@ -147,6 +287,17 @@ func getGroupConcatMaxLen(ctx *sql.Context) int64 {
 	return gcml.(int64)
 }
 
+// getGroupConcatMemLimit returns the number of rows GroupConcat is willing to hold in memory for a single
+// group before spilling the rest to disk, as controlled by the group_concat_mem_limit session variable.
+func getGroupConcatMemLimit(ctx *sql.Context) int64 {
+	_, gcml := ctx.Get("group_concat_mem_limit")
+	limit, ok := gcml.(int64)
+	if !ok || limit <= 0 {
+		return defaultGroupConcatMemLimit
+	}
+	return limit
+}
+
 func evalExprs(ctx *sql.Context, exprs []sql.Expression, row sql.Row) (sql.Row, error) {
 	result := make(sql.Row, len(exprs))
 	for i, expr := range exprs {
@@ -206,9 +357,22 @@ func (g *GroupConcat) String() string {
 
 // TODO: Have variable return types for group concat
 func (g *GroupConcat) Type() sql.Type {
+	if g.binary {
+		return sql.LongBlob
+	}
 	return sql.LongText
 }
 
+// CollationCoercibility implements the sql.CollationCoercible interface, so that ORDER BY / HAVING over the
+// concatenated result compares using the collation it inherited from its first argument, rather than
+// falling back to the connection's default collation.
+func (g *GroupConcat) CollationCoercibility(ctx *sql.Context) (sql.CollationID, byte) {
+	if g.binary {
+		return sql.Collation_binary, 2
+	}
+	return g.collation, 2
+}
+
 func (g *GroupConcat) IsNullable() bool {
 	return false
 }
@@ -232,4 +396,4 @@ func (g *GroupConcat) WithChildren(children ...sql.Expression) (sql.Expression,
 
 func (g *GroupConcat) FunctionName() string {
 	return "group_concat"
-}
\ No newline at end of file
+}
@@ -0,0 +1,167 @@
+// Copyright 2021 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aggregation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dolthub/go-mysql-server/sql"
+	"github.com/dolthub/go-mysql-server/sql/expression"
+)
+
+// newGroupConcatTestContext returns a context with group_concat_max_len and group_concat_mem_limit set to
+// permissive defaults, the way the engine would before evaluating a GROUP_CONCAT expression.
+func newGroupConcatTestContext() *sql.Context {
+	ctx := sql.NewEmptyContext()
+	if err := ctx.SetSessionVariable(ctx, "group_concat_max_len", int64(1024)); err != nil {
+		panic(err)
+	}
+	if err := ctx.SetSessionVariable(ctx, "group_concat_mem_limit", defaultGroupConcatMemLimit); err != nil {
+		panic(err)
+	}
+	return ctx
+}
+
+func evalGroupConcat(t *testing.T, gc *GroupConcat, rows []sql.Row) interface{} {
+	t.Helper()
+
+	ctx := newGroupConcatTestContext()
+	buf := gc.NewBuffer()
+	for _, row := range rows {
+		require.NoError(t, gc.Update(ctx, buf, row))
+	}
+
+	result, err := gc.Eval(ctx, buf)
+	require.NoError(t, err)
+	return result
+}
+
+func TestGroupConcatMultipleExpressions(t *testing.T) {
+	a := expression.NewGetField(0, sql.LongText, "a", true)
+	b := expression.NewGetField(1, sql.LongText, "b", true)
+
+	gc, err := NewGroupConcat("", nil, ",", []sql.Expression{a, b})
+	require.NoError(t, err)
+
+	result := evalGroupConcat(t, gc, []sql.Row{
+		sql.NewRow("x", "1"),
+		sql.NewRow("y", "2"),
+	})
+
+	require.Equal(t, "x1,y2", result)
+}
+
+func TestGroupConcatNumericAndStringMix(t *testing.T) {
+	a := expression.NewGetField(0, sql.Int64, "a", true)
+	b := expression.NewGetField(1, sql.LongText, "b", true)
+
+	gc, err := NewGroupConcat("", nil, "-", []sql.Expression{a, b})
+	require.NoError(t, err)
+
+	result := evalGroupConcat(t, gc, []sql.Row{
+		sql.NewRow(int64(1), "x"),
+		sql.NewRow(int64(2), "y"),
+	})
+
+	require.Equal(t, "1x-2y", result)
+}
+
+func TestGroupConcatSkipsRowWithAnyNullExpression(t *testing.T) {
+	a := expression.NewGetField(0, sql.LongText, "a", true)
+	b := expression.NewGetField(1, sql.LongText, "b", true)
+
+	gc, err := NewGroupConcat("", nil, ",", []sql.Expression{a, b})
+	require.NoError(t, err)
+
+	result := evalGroupConcat(t, gc, []sql.Row{
+		sql.NewRow("x", "1"),
+		sql.NewRow(nil, "2"),
+		sql.NewRow("z", "3"),
+	})
+
+	require.Equal(t, "x1,z3", result)
+}
+
+func TestGroupConcatDistinctAcrossTuple(t *testing.T) {
+	a := expression.NewGetField(0, sql.LongText, "a", true)
+	b := expression.NewGetField(1, sql.LongText, "b", true)
+
+	gc, err := NewGroupConcat("distinct", nil, ",", []sql.Expression{a, b})
+	require.NoError(t, err)
+
+	// ("a", "bc") and ("ab", "c") concatenate to the same string but are different tuples, so both must
+	// be kept under DISTINCT.
+	result := evalGroupConcat(t, gc, []sql.Row{
+		sql.NewRow("a", "bc"),
+		sql.NewRow("ab", "c"),
+		sql.NewRow("a", "bc"),
+	})
+
+	require.Equal(t, "abc,abc", result)
+}
+
+func TestGroupConcatDistinctBinaryElementsAreNotAmbiguous(t *testing.T) {
+	a := expression.NewGetField(0, sql.LongBlob, "a", true)
+	b := expression.NewGetField(1, sql.LongBlob, "b", true)
+
+	gc, err := NewGroupConcat("distinct", nil, "", []sql.Expression{a, b})
+	require.NoError(t, err)
+
+	// ("a\x00b", "c") and ("a", "b\x00c") joined naively on a NUL separator collide; the distinct key must
+	// keep them apart.
+	result := evalGroupConcat(t, gc, []sql.Row{
+		sql.NewRow([]byte("a\x00b"), []byte("c")),
+		sql.NewRow([]byte("a"), []byte("b\x00c")),
+	})
+
+	require.Equal(t, []byte{'a', 0, 'b', 'c', 'a', 'b', 0, 'c'}, result)
+}
+
+func TestGroupConcatBinaryArgumentAnywhereForcesBinaryMode(t *testing.T) {
+	text := expression.NewGetField(0, sql.LongText, "name", true)
+	blob := expression.NewGetField(1, sql.LongBlob, "photo", true)
+
+	gc, err := NewGroupConcat("", nil, "", []sql.Expression{text, blob})
+	require.NoError(t, err)
+
+	result := evalGroupConcat(t, gc, []sql.Row{
+		sql.NewRow("a", []byte("x\x00y")),
+	})
+
+	require.Equal(t, []byte{'a', 'x', 0, 'y'}, result)
+}
+
+func TestGroupConcatTruncatesAtMaxLenInsteadOfDroppingTheOverflowingRow(t *testing.T) {
+	ctx := sql.NewEmptyContext()
+	require.NoError(t, ctx.SetSessionVariable(ctx, "group_concat_max_len", int64(10)))
+	require.NoError(t, ctx.SetSessionVariable(ctx, "group_concat_mem_limit", defaultGroupConcatMemLimit))
+
+	col := expression.NewGetField(0, sql.LongText, "a", true)
+	gc, err := NewGroupConcat("", nil, ",", []sql.Expression{col})
+	require.NoError(t, err)
+
+	buf := gc.NewBuffer()
+	require.NoError(t, gc.Update(ctx, buf, sql.NewRow("abcde")))
+	require.NoError(t, gc.Update(ctx, buf, sql.NewRow("fghijklmno")))
+
+	result, err := gc.Eval(ctx, buf)
+	require.NoError(t, err)
+
+	// "abcde,fghijklmno" cut to 10 bytes is "abcde,fghi", not "abcde" - the row that crosses
+	// group_concat_max_len must still be appended and truncated at the byte boundary, not dropped outright.
+	require.Equal(t, "abcde,fghi", result)
+}
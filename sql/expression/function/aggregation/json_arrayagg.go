@@ -0,0 +1,141 @@
+// Copyright 2021 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aggregation
+
+import (
+	"strings"
+
+	"github.com/dolthub/go-mysql-server/sql"
+)
+
+// JSONArrayAgg implements the JSON_ARRAYAGG(expr [ORDER BY ...]) aggregation. It reuses the same
+// listAggBuffer spill/ordering plumbing GroupConcat is built on, but produces a sql.JSON array rather than
+// a separator-joined, group_concat_max_len-truncated string.
+type JSONArrayAgg struct {
+	sf   sql.SortFields
+	expr sql.Expression
+}
+
+var _ sql.FunctionExpression = &JSONArrayAgg{}
+var _ sql.Aggregation = &JSONArrayAgg{}
+
+func NewEmptyJSONArrayAgg() sql.Expression {
+	return &JSONArrayAgg{}
+}
+
+func NewJSONArrayAgg(orderBy sql.SortFields, expr sql.Expression) (*JSONArrayAgg, error) {
+	return &JSONArrayAgg{sf: orderBy, expr: expr}, nil
+}
+
+// NewBuffer creates a new buffer for the aggregation.
+func (j *JSONArrayAgg) NewBuffer() sql.Row {
+	return sql.NewRow(newListAggBuffer())
+}
+
+// Update implements the Aggregation interface.
+func (j *JSONArrayAgg) Update(ctx *sql.Context, buffer, originalRow sql.Row) error {
+	buf := buffer[0].(*listAggBuffer)
+
+	v, err := j.expr.Eval(ctx, originalRow)
+	if err != nil {
+		return err
+	}
+
+	// JSON_ARRAYAGG skips rows whose value evaluates to NULL, rather than contributing a JSON null.
+	if v == nil {
+		return nil
+	}
+
+	row := append(originalRow, nil, v)
+	return buf.appendOrSpill(ctx, j.sf, getGroupConcatMemLimit(ctx), row)
+}
+
+// Merge implements the Aggregation interface.
+func (j *JSONArrayAgg) Merge(ctx *sql.Context, buffer, partial sql.Row) error {
+	return j.Update(ctx, buffer, partial)
+}
+
+// Eval implements the Aggregation interface.
+func (j *JSONArrayAgg) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
+	buf := row[0].(*listAggBuffer)
+
+	rows, err := buf.sortedRows(ctx, j.sf)
+	if err != nil {
+		return nil, err
+	}
+	defer buf.close()
+
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	arr := make([]interface{}, len(rows))
+	for i, r := range rows {
+		arr[i] = r[len(r)-1]
+	}
+
+	return sql.JSONDocument{Val: arr}, nil
+}
+
+func (j *JSONArrayAgg) Resolved() bool {
+	return j.expr.Resolved()
+}
+
+func (j *JSONArrayAgg) String() string {
+	sb := strings.Builder{}
+	sb.WriteString("json_arrayagg(")
+	sb.WriteString(j.expr.String())
+
+	if len(j.sf) > 0 {
+		sb.WriteString(" order by ")
+		for i, ob := range j.sf {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			sb.WriteString(ob.String())
+		}
+	}
+
+	sb.WriteString(")")
+
+	return sb.String()
+}
+
+func (j *JSONArrayAgg) Type() sql.Type {
+	return sql.JSON
+}
+
+func (j *JSONArrayAgg) IsNullable() bool {
+	return false
+}
+
+func (j *JSONArrayAgg) Children() []sql.Expression {
+	return append(j.sf.ToExpressions(), j.expr)
+}
+
+func (j *JSONArrayAgg) WithChildren(children ...sql.Expression) (sql.Expression, error) {
+	if len(children) == 0 {
+		return nil, sql.ErrInvalidChildrenNumber.New(JSONArrayAgg{}, len(children), 1)
+	}
+
+	delim := len(j.sf)
+	orderByExpr := children[:delim]
+
+	return NewJSONArrayAgg(j.sf.FromExpressions(orderByExpr), children[delim])
+}
+
+func (j *JSONArrayAgg) FunctionName() string {
+	return "json_arrayagg"
+}
@@ -0,0 +1,59 @@
+// Copyright 2021 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aggregation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dolthub/go-mysql-server/sql"
+	"github.com/dolthub/go-mysql-server/sql/expression"
+)
+
+func TestJSONArrayAggSkipsNullValues(t *testing.T) {
+	ctx := newGroupConcatTestContext()
+
+	col := expression.NewGetField(0, sql.LongText, "a", true)
+	agg, err := NewJSONArrayAgg(nil, col)
+	require.NoError(t, err)
+
+	buf := agg.NewBuffer()
+	require.NoError(t, agg.Update(ctx, buf, sql.NewRow("x")))
+	require.NoError(t, agg.Update(ctx, buf, sql.NewRow(nil)))
+	require.NoError(t, agg.Update(ctx, buf, sql.NewRow("y")))
+
+	result, err := agg.Eval(ctx, buf)
+	require.NoError(t, err)
+
+	doc, ok := result.(sql.JSONDocument)
+	require.True(t, ok)
+	require.Equal(t, []interface{}{"x", "y"}, doc.Val)
+}
+
+func TestJSONArrayAggEmptyGroupReturnsNil(t *testing.T) {
+	ctx := newGroupConcatTestContext()
+
+	col := expression.NewGetField(0, sql.LongText, "a", true)
+	agg, err := NewJSONArrayAgg(nil, col)
+	require.NoError(t, err)
+
+	buf := agg.NewBuffer()
+	require.NoError(t, agg.Update(ctx, buf, sql.NewRow(nil)))
+
+	result, err := agg.Eval(ctx, buf)
+	require.NoError(t, err)
+	require.Nil(t, result)
+}
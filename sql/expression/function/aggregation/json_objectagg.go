@@ -0,0 +1,141 @@
+// Copyright 2021 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aggregation
+
+import (
+	"fmt"
+
+	"github.com/dolthub/go-mysql-server/sql"
+)
+
+// JSONObjectAgg implements the JSON_OBJECTAGG(key, value [ORDER BY ...]) aggregation, sharing the same
+// listAggBuffer plumbing as GroupConcat and JSONArrayAgg. Rows are folded into the result object in arrival
+// order, or in ORDER BY order when one is given (spilled/merged as needed): if two rows produce the same key,
+// whichever is folded later overwrites the earlier one.
+type JSONObjectAgg struct {
+	sf         sql.SortFields
+	key, value sql.Expression
+}
+
+var _ sql.FunctionExpression = &JSONObjectAgg{}
+var _ sql.Aggregation = &JSONObjectAgg{}
+
+func NewEmptyJSONObjectAgg() sql.Expression {
+	return &JSONObjectAgg{}
+}
+
+func NewJSONObjectAgg(orderBy sql.SortFields, key, value sql.Expression) (*JSONObjectAgg, error) {
+	return &JSONObjectAgg{sf: orderBy, key: key, value: value}, nil
+}
+
+// NewBuffer creates a new buffer for the aggregation.
+func (j *JSONObjectAgg) NewBuffer() sql.Row {
+	return sql.NewRow(newListAggBuffer())
+}
+
+// Update implements the Aggregation interface.
+func (j *JSONObjectAgg) Update(ctx *sql.Context, buffer, originalRow sql.Row) error {
+	buf := buffer[0].(*listAggBuffer)
+
+	k, err := j.key.Eval(ctx, originalRow)
+	if err != nil {
+		return err
+	}
+
+	// A NULL key can't be represented as a JSON object member, so the row is skipped entirely.
+	if k == nil {
+		return nil
+	}
+
+	ks, err := sql.LongText.Convert(k)
+	if err != nil {
+		return err
+	}
+	if ks == nil {
+		return nil
+	}
+
+	v, err := j.value.Eval(ctx, originalRow)
+	if err != nil {
+		return err
+	}
+
+	row := append(originalRow, nil, ks.(string), v)
+	return buf.appendOrSpill(ctx, j.sf, getGroupConcatMemLimit(ctx), row)
+}
+
+// Merge implements the Aggregation interface.
+func (j *JSONObjectAgg) Merge(ctx *sql.Context, buffer, partial sql.Row) error {
+	return j.Update(ctx, buffer, partial)
+}
+
+// Eval implements the Aggregation interface.
+func (j *JSONObjectAgg) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
+	buf := row[0].(*listAggBuffer)
+
+	rows, err := buf.sortedRows(ctx, j.sf)
+	if err != nil {
+		return nil, err
+	}
+	defer buf.close()
+
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	obj := make(map[string]interface{}, len(rows))
+	for _, r := range rows {
+		n := len(r)
+		obj[r[n-2].(string)] = r[n-1]
+	}
+
+	return sql.JSONDocument{Val: obj}, nil
+}
+
+func (j *JSONObjectAgg) Resolved() bool {
+	return j.key.Resolved() && j.value.Resolved()
+}
+
+func (j *JSONObjectAgg) String() string {
+	return fmt.Sprintf("json_objectagg(%s, %s)", j.key.String(), j.value.String())
+}
+
+func (j *JSONObjectAgg) Type() sql.Type {
+	return sql.JSON
+}
+
+func (j *JSONObjectAgg) IsNullable() bool {
+	return false
+}
+
+func (j *JSONObjectAgg) Children() []sql.Expression {
+	return append(j.sf.ToExpressions(), j.key, j.value)
+}
+
+func (j *JSONObjectAgg) WithChildren(children ...sql.Expression) (sql.Expression, error) {
+	if len(children) < 2 {
+		return nil, sql.ErrInvalidChildrenNumber.New(JSONObjectAgg{}, len(children), 2)
+	}
+
+	delim := len(j.sf)
+	orderByExpr := children[:delim]
+	rest := children[delim:]
+
+	return NewJSONObjectAgg(j.sf.FromExpressions(orderByExpr), rest[0], rest[1])
+}
+
+func (j *JSONObjectAgg) FunctionName() string {
+	return "json_objectagg"
+}
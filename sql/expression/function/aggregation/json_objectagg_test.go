@@ -0,0 +1,65 @@
+// Copyright 2021 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aggregation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dolthub/go-mysql-server/sql"
+	"github.com/dolthub/go-mysql-server/sql/expression"
+)
+
+func TestJSONObjectAggLastValueForKeyWins(t *testing.T) {
+	ctx := newGroupConcatTestContext()
+
+	key := expression.NewGetField(0, sql.LongText, "k", true)
+	val := expression.NewGetField(1, sql.LongText, "v", true)
+	agg, err := NewJSONObjectAgg(nil, key, val)
+	require.NoError(t, err)
+
+	buf := agg.NewBuffer()
+	require.NoError(t, agg.Update(ctx, buf, sql.NewRow("a", "1")))
+	require.NoError(t, agg.Update(ctx, buf, sql.NewRow("a", "2")))
+	require.NoError(t, agg.Update(ctx, buf, sql.NewRow("b", "3")))
+
+	result, err := agg.Eval(ctx, buf)
+	require.NoError(t, err)
+
+	doc, ok := result.(sql.JSONDocument)
+	require.True(t, ok)
+	require.Equal(t, map[string]interface{}{"a": "2", "b": "3"}, doc.Val)
+}
+
+func TestJSONObjectAggSkipsNullKeys(t *testing.T) {
+	ctx := newGroupConcatTestContext()
+
+	key := expression.NewGetField(0, sql.LongText, "k", true)
+	val := expression.NewGetField(1, sql.LongText, "v", true)
+	agg, err := NewJSONObjectAgg(nil, key, val)
+	require.NoError(t, err)
+
+	buf := agg.NewBuffer()
+	require.NoError(t, agg.Update(ctx, buf, sql.NewRow(nil, "1")))
+	require.NoError(t, agg.Update(ctx, buf, sql.NewRow("a", "2")))
+
+	result, err := agg.Eval(ctx, buf)
+	require.NoError(t, err)
+
+	doc, ok := result.(sql.JSONDocument)
+	require.True(t, ok)
+	require.Equal(t, map[string]interface{}{"a": "2"}, doc.Val)
+}
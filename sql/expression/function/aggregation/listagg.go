@@ -0,0 +1,273 @@
+// Copyright 2021 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aggregation
+
+import (
+	"container/heap"
+	"encoding/gob"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/dolthub/go-mysql-server/sql"
+	"github.com/dolthub/go-mysql-server/sql/expression"
+)
+
+// gob only needs registration for concrete types it hasn't already registered for its own builtins (bool,
+// the fixed-size numeric kinds, string, []byte, and so on); it panics at encode/decode time for anything
+// else carried through an interface{}, which is how every element of a spilled sql.Row travels. Register
+// every non-builtin concrete type a SQL value can hold so that ORDER BY over a DATETIME, DECIMAL, or JSON
+// column doesn't fail once a group grows past group_concat_mem_limit and starts spilling to disk.
+func init() {
+	gob.Register(time.Time{})
+	gob.Register(decimal.Decimal{})
+	gob.Register(sql.JSONDocument{})
+}
+
+// listAggBuffer is the shared accumulation buffer behind every "list" aggregation - GroupConcat,
+// JSONArrayAgg, and JSONObjectAgg - which all reduce to collecting a (possibly ordered, possibly distinct)
+// list of row-tagged values and combining them in Eval. It bounds how many rows it holds in memory via
+// appendOrSpill, spilling the rest to disk through a rowSpiller when an ORDER BY is present.
+type listAggBuffer struct {
+	rows        []sql.Row
+	distinctSet map[string]bool
+	spiller     *rowSpiller
+}
+
+func newListAggBuffer() *listAggBuffer {
+	return &listAggBuffer{distinctSet: make(map[string]bool)}
+}
+
+// appendOrSpill adds row to the buffer. With no ORDER BY (sf == nil), rows only ever need to be held in
+// arrival order, so they're simply appended. With an ORDER BY, the final order can't be known until every
+// row has arrived, so the buffer keeps up to memLimit rows in memory and spills the rest to a temp file via
+// a rowSpiller, merging everything back in sorted order in sortedRows.
+func (b *listAggBuffer) appendOrSpill(ctx *sql.Context, sf sql.SortFields, memLimit int64, row sql.Row) error {
+	if sf == nil || int64(len(b.rows)) < memLimit {
+		b.rows = append(b.rows, row)
+		return nil
+	}
+
+	if b.spiller == nil {
+		b.spiller = newRowSpiller(ctx, sf, memLimit)
+	}
+	return b.spiller.add(row)
+}
+
+// sortedRows returns every row the buffer has accumulated, merging back anything spilled to disk and
+// sorting the result according to sf (a no-op if sf is nil). Callers must call close once they're done
+// with the result, to remove any spilled temp file.
+func (b *listAggBuffer) sortedRows(ctx *sql.Context, sf sql.SortFields) ([]sql.Row, error) {
+	rows := b.rows
+	if b.spiller != nil {
+		merged, err := b.spiller.mergeWith(rows)
+		if err != nil {
+			return nil, err
+		}
+		return merged, nil
+	}
+
+	if sf != nil {
+		sorter := &expression.Sorter{SortFields: sf, Rows: rows, Ctx: ctx}
+		sort.Stable(sorter)
+		if sorter.LastError != nil {
+			return nil, sorter.LastError
+		}
+	}
+
+	return rows, nil
+}
+
+// close removes any temp file the buffer spilled rows to.
+func (b *listAggBuffer) close() error {
+	if b.spiller == nil {
+		return nil
+	}
+	return b.spiller.close()
+}
+
+// Close implements io.Closer. Eval already cleans up after itself via close, but a query plan doesn't
+// always reach Eval for every buffer it creates - it can be cancelled, or fail at an earlier stage of the
+// plan - so the engine should type-assert a list aggregation's buffer to io.Closer and call Close
+// unconditionally once it's done with the group, to guarantee any spilled temp file under os.TempDir()
+// gets removed.
+func (b *listAggBuffer) Close() error {
+	return b.close()
+}
+
+// rowSpiller buffers rows past a list aggregation's in-memory cap and spills them to a temp file on disk,
+// gob-encoded in sorted batches, so that sortedRows can merge them back in without holding the whole group
+// in memory at once.
+type rowSpiller struct {
+	ctx       *sql.Context
+	sf        sql.SortFields
+	batchSize int64
+
+	pending []sql.Row
+
+	file    *os.File
+	enc     *gob.Encoder
+	batches int
+}
+
+func newRowSpiller(ctx *sql.Context, sf sql.SortFields, batchSize int64) *rowSpiller {
+	return &rowSpiller{ctx: ctx, sf: sf, batchSize: batchSize}
+}
+
+// add buffers row, flushing a sorted batch to disk once batchSize rows have accumulated.
+func (s *rowSpiller) add(row sql.Row) error {
+	s.pending = append(s.pending, row)
+	if int64(len(s.pending)) < s.batchSize {
+		return nil
+	}
+	return s.flush()
+}
+
+func (s *rowSpiller) flush() error {
+	if len(s.pending) == 0 {
+		return nil
+	}
+
+	if s.file == nil {
+		f, err := ioutil.TempFile("", "listagg_spill_*.gob")
+		if err != nil {
+			return err
+		}
+		s.file = f
+		s.enc = gob.NewEncoder(f)
+	}
+
+	s.sortRows(s.pending)
+	if err := s.enc.Encode(s.pending); err != nil {
+		return err
+	}
+	s.batches++
+	s.pending = nil
+	return nil
+}
+
+func (s *rowSpiller) sortRows(rows []sql.Row) {
+	sorter := &expression.Sorter{
+		SortFields: s.sf,
+		Rows:       rows,
+		Ctx:        s.ctx,
+	}
+	sort.Stable(sorter)
+}
+
+// mergeWith k-way merges the rows already in memory with every batch spilled to disk, returning the fully
+// merged, sorted result.
+func (s *rowSpiller) mergeWith(inMemory []sql.Row) ([]sql.Row, error) {
+	if err := s.flush(); err != nil {
+		return nil, err
+	}
+	if s.file == nil {
+		s.sortRows(inMemory)
+		return inMemory, nil
+	}
+
+	if _, err := s.file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	dec := gob.NewDecoder(s.file)
+
+	s.sortRows(inMemory)
+	sources := [][]sql.Row{inMemory}
+	for i := 0; i < s.batches; i++ {
+		var batch []sql.Row
+		if err := dec.Decode(&batch); err != nil {
+			return nil, err
+		}
+		sources = append(sources, batch)
+	}
+
+	return mergeSortedRows(s.sf, s.ctx, sources), nil
+}
+
+func (s *rowSpiller) close() error {
+	if s.file == nil {
+		return nil
+	}
+	name := s.file.Name()
+	s.file.Close()
+	return os.Remove(name)
+}
+
+// mergeSortedRows performs a k-way merge of already-sorted row slices using the same ordering rules as
+// expression.Sorter, without re-sorting the combined result.
+func mergeSortedRows(sf sql.SortFields, ctx *sql.Context, sources [][]sql.Row) []sql.Row {
+	h := &rowHeap{sf: sf, ctx: ctx}
+	total := 0
+	for i, src := range sources {
+		total += len(src)
+		if len(src) > 0 {
+			heap.Push(h, &heapSource{rows: src, idx: i})
+		}
+	}
+
+	merged := make([]sql.Row, 0, total)
+	for h.Len() > 0 {
+		top := h.items[0]
+		merged = append(merged, top.rows[0])
+		top.rows = top.rows[1:]
+		if len(top.rows) == 0 {
+			heap.Pop(h)
+		} else {
+			heap.Fix(h, 0)
+		}
+	}
+
+	return merged
+}
+
+type heapSource struct {
+	rows []sql.Row
+	idx  int
+}
+
+// rowHeap is a min-heap over the head rows of several already-sorted sources, ordered using the same
+// expression.Sorter comparison the list aggregations' final sort uses.
+type rowHeap struct {
+	sf    sql.SortFields
+	ctx   *sql.Context
+	items []*heapSource
+}
+
+func (h *rowHeap) Len() int { return len(h.items) }
+
+func (h *rowHeap) Less(i, j int) bool {
+	sorter := &expression.Sorter{
+		SortFields: h.sf,
+		Rows:       []sql.Row{h.items[i].rows[0], h.items[j].rows[0]},
+		Ctx:        h.ctx,
+	}
+	return sorter.Less(0, 1)
+}
+
+func (h *rowHeap) Swap(i, j int) { h.items[i], h.items[j] = h.items[j], h.items[i] }
+
+func (h *rowHeap) Push(x interface{}) { h.items = append(h.items, x.(*heapSource)) }
+
+func (h *rowHeap) Pop() interface{} {
+	old := h.items
+	n := len(old)
+	item := old[n-1]
+	h.items = old[:n-1]
+	return item
+}
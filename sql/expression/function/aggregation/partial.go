@@ -0,0 +1,178 @@
+// Copyright 2021 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aggregation
+
+import (
+	"bytes"
+	"encoding/gob"
+
+	"github.com/dolthub/go-mysql-server/sql"
+)
+
+// PartialAggregation is implemented by aggregations that can split their work into an arbitrary number of
+// partial computations and merge the results back together, rather than forcing every contributing row
+// through a single buffer. It lets the engine shard a GROUP BY's input rows across goroutines, run
+// NewPartialBuffer/UpdatePartial in parallel, and fold the serialized results together with
+// NewFinalBuffer/MergePartial/Finalize - mirroring the local/final split CockroachDB's distsql aggregators
+// make.
+//
+// This is an opt-in extension point: nothing in this package's own tree calls LookupPartialAggregation
+// outside of tests, because the GROUP BY executor/planner that would shard work and call it lives outside
+// this source tree and isn't present here. GroupConcat.Merge still folds rows in one at a time via Update.
+// An executor adopting parallel grouping should call LookupPartialAggregation itself; see
+// partial_test.go for the shard/serialize/merge/finalize sequence it would drive.
+type PartialAggregation interface {
+	sql.Aggregation
+
+	// NewPartialBuffer creates a new buffer for accumulating a partial aggregation over a shard of a
+	// group's rows.
+	NewPartialBuffer() sql.Row
+
+	// UpdatePartial folds row into a partial buffer created by NewPartialBuffer.
+	UpdatePartial(ctx *sql.Context, buffer, row sql.Row) error
+
+	// Serialize encodes a partial buffer into a wire-transferable form that a peer's MergePartial can
+	// consume.
+	Serialize(ctx *sql.Context, buffer sql.Row) ([]byte, error)
+
+	// NewFinalBuffer creates a new buffer for merging serialized partial states together.
+	NewFinalBuffer() sql.Row
+
+	// MergePartial decodes a serialized partial state produced by Serialize and folds it into buffer.
+	MergePartial(ctx *sql.Context, buffer sql.Row, partial []byte) error
+
+	// Finalize produces the aggregation's final value from a buffer built up by MergePartial.
+	Finalize(ctx *sql.Context, buffer sql.Row) (interface{}, error)
+}
+
+// partialAggregationProbe checks whether a sql.Expression is (or wraps) an aggregation registered under a
+// given function name, returning its PartialAggregation view.
+type partialAggregationProbe func(sql.Expression) (PartialAggregation, bool)
+
+// partialAggregationRegistry lets third-party aggregations opt into the partial/final pipeline under their
+// own FunctionName without this package importing them.
+var partialAggregationRegistry = make(map[string]partialAggregationProbe)
+
+// RegisterPartialAggregation registers probe under name so that LookupPartialAggregation can recognize
+// third-party sql.Aggregation implementations that support the two-phase partial/final pipeline.
+func RegisterPartialAggregation(name string, probe partialAggregationProbe) {
+	partialAggregationRegistry[name] = probe
+}
+
+// LookupPartialAggregation returns the PartialAggregation view of agg, if agg directly implements it or is
+// registered under its FunctionName via RegisterPartialAggregation.
+func LookupPartialAggregation(agg sql.Aggregation) (PartialAggregation, bool) {
+	if pa, ok := agg.(PartialAggregation); ok {
+		return pa, true
+	}
+
+	fn, ok := agg.(sql.FunctionExpression)
+	if !ok {
+		return nil, false
+	}
+
+	expr, ok := agg.(sql.Expression)
+	if !ok {
+		return nil, false
+	}
+
+	probe, ok := partialAggregationRegistry[fn.FunctionName()]
+	if !ok {
+		return nil, false
+	}
+
+	return probe(expr)
+}
+
+func init() {
+	RegisterPartialAggregation("group_concat", func(e sql.Expression) (PartialAggregation, bool) {
+		gc, ok := e.(*GroupConcat)
+		return gc, ok
+	})
+}
+
+// groupConcatPartial is the wire-serializable partial state a GroupConcat shard produces: the rows it has
+// seen so far (still carrying their original columns, for ORDER BY) and the set of distinct values it has
+// already emitted, so a peer merging this partial in doesn't re-admit a duplicate.
+type groupConcatPartial struct {
+	Rows        []sql.Row
+	DistinctSet map[string]bool
+}
+
+var _ PartialAggregation = (*GroupConcat)(nil)
+
+// NewPartialBuffer implements the PartialAggregation interface.
+func (g *GroupConcat) NewPartialBuffer() sql.Row {
+	return g.NewBuffer()
+}
+
+// UpdatePartial implements the PartialAggregation interface.
+func (g *GroupConcat) UpdatePartial(ctx *sql.Context, buffer, row sql.Row) error {
+	return g.Update(ctx, buffer, row)
+}
+
+// Serialize implements the PartialAggregation interface, gob-encoding the rows and distinct set a shard
+// has accumulated so far. Any not-yet-merged disk spill is flushed into the encoded rows first, since a
+// partial's spill file wouldn't survive a trip across the wire to another goroutine or node.
+func (g *GroupConcat) Serialize(ctx *sql.Context, buffer sql.Row) ([]byte, error) {
+	buf := buffer[0].(*groupConcatBuffer)
+
+	rows, err := buf.sortedRows(ctx, g.sf)
+	if err != nil {
+		return nil, err
+	}
+	defer buf.close()
+
+	var b bytes.Buffer
+	if err := gob.NewEncoder(&b).Encode(&groupConcatPartial{Rows: rows, DistinctSet: buf.distinctSet}); err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
+
+// NewFinalBuffer implements the PartialAggregation interface.
+func (g *GroupConcat) NewFinalBuffer() sql.Row {
+	return g.NewBuffer()
+}
+
+// MergePartial implements the PartialAggregation interface, decoding a partial produced by Serialize and
+// folding its rows and distinct set into buffer. Each row is re-admitted through Update, so under DISTINCT
+// a tuple that buffer already holds is not appended a second time.
+func (g *GroupConcat) MergePartial(ctx *sql.Context, buffer sql.Row, partial []byte) error {
+	var p groupConcatPartial
+	if err := gob.NewDecoder(bytes.NewReader(partial)).Decode(&p); err != nil {
+		return err
+	}
+
+	// Every row still carries its original columns ahead of the two slots Update appended to it.
+	for _, r := range p.Rows {
+		if err := g.Update(ctx, buffer, r[:len(r)-2]); err != nil {
+			return err
+		}
+	}
+
+	buf := buffer[0].(*groupConcatBuffer)
+	for k := range p.DistinctSet {
+		buf.distinctSet[k] = true
+	}
+
+	return nil
+}
+
+// Finalize implements the PartialAggregation interface.
+func (g *GroupConcat) Finalize(ctx *sql.Context, buffer sql.Row) (interface{}, error) {
+	return g.Eval(ctx, buffer)
+}
@@ -0,0 +1,85 @@
+// Copyright 2021 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aggregation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dolthub/go-mysql-server/sql"
+	"github.com/dolthub/go-mysql-server/sql/expression"
+)
+
+func TestGroupConcatPartialAggregationRoundTrip(t *testing.T) {
+	ctx := newGroupConcatTestContext()
+
+	col := expression.NewGetField(0, sql.LongText, "a", true)
+	gc, err := NewGroupConcat("", nil, ",", []sql.Expression{col})
+	require.NoError(t, err)
+
+	pa, ok := LookupPartialAggregation(gc)
+	require.True(t, ok)
+
+	shard1 := pa.NewPartialBuffer()
+	require.NoError(t, pa.UpdatePartial(ctx, shard1, sql.NewRow("x")))
+	partial1, err := pa.Serialize(ctx, shard1)
+	require.NoError(t, err)
+
+	shard2 := pa.NewPartialBuffer()
+	require.NoError(t, pa.UpdatePartial(ctx, shard2, sql.NewRow("y")))
+	partial2, err := pa.Serialize(ctx, shard2)
+	require.NoError(t, err)
+
+	final := pa.NewFinalBuffer()
+	require.NoError(t, pa.MergePartial(ctx, final, partial1))
+	require.NoError(t, pa.MergePartial(ctx, final, partial2))
+
+	result, err := pa.Finalize(ctx, final)
+	require.NoError(t, err)
+	require.Equal(t, "x,y", result)
+}
+
+func TestGroupConcatPartialAggregationMergesDistinctSets(t *testing.T) {
+	ctx := newGroupConcatTestContext()
+
+	col := expression.NewGetField(0, sql.LongText, "a", true)
+	gc, err := NewGroupConcat("distinct", nil, ",", []sql.Expression{col})
+	require.NoError(t, err)
+
+	pa, ok := LookupPartialAggregation(gc)
+	require.True(t, ok)
+
+	shard1 := pa.NewPartialBuffer()
+	require.NoError(t, pa.UpdatePartial(ctx, shard1, sql.NewRow("x")))
+	partial1, err := pa.Serialize(ctx, shard1)
+	require.NoError(t, err)
+
+	// A second shard sees the same value "x" again; merging both partials into one final buffer must not
+	// double-count it.
+	shard2 := pa.NewPartialBuffer()
+	require.NoError(t, pa.UpdatePartial(ctx, shard2, sql.NewRow("x")))
+	require.NoError(t, pa.UpdatePartial(ctx, shard2, sql.NewRow("y")))
+	partial2, err := pa.Serialize(ctx, shard2)
+	require.NoError(t, err)
+
+	final := pa.NewFinalBuffer()
+	require.NoError(t, pa.MergePartial(ctx, final, partial1))
+	require.NoError(t, pa.MergePartial(ctx, final, partial2))
+
+	result, err := pa.Finalize(ctx, final)
+	require.NoError(t, err)
+	require.Equal(t, "x,y", result)
+}
@@ -0,0 +1,74 @@
+// Copyright 2021 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aggregation
+
+import (
+	"github.com/dolthub/go-mysql-server/sql"
+)
+
+// StreamingAggregation is implemented by aggregations that can report, while a group is still being
+// accumulated, whether the value Eval will eventually produce is already pinned down well enough to decide
+// a sargable HAVING predicate over it. When Monotonic returns true for a buffer, the GROUP BY executor can
+// stop calling Update for that group's remaining rows instead of materializing all of them - the same
+// early-decision trick CockroachDB's group.go uses to avoid fully building groups HAVING has already
+// resolved.
+//
+// This is an opt-in extension point: the GROUP BY executor that would call CanStream/Monotonic to skip
+// Update calls lives outside this source tree and isn't present here, so outside of tests nothing in this
+// package calls either method yet. See streaming_test.go for the CanStream/Monotonic sequence such an
+// executor would drive.
+type StreamingAggregation interface {
+	sql.Aggregation
+
+	// CanStream reports whether this aggregation supports the early-decision optimization at all for how
+	// it's configured (e.g. GroupConcat can't stream when it has an ORDER BY, since a later row could
+	// still sort ahead of ones already seen and change the truncated output).
+	CanStream() bool
+
+	// Monotonic reports whether, for the given buffer, having's truth value against this group is already
+	// decided and can't be reversed by any row Update hasn't seen yet - e.g. because buffer's eventual
+	// value is already fixed, or because having is sargable against a quantity (like output length) that
+	// can only move in one direction as more rows arrive. having is the predicate the GROUP BY executor is
+	// trying to decide; an implementation must treat it as opaque except where it can prove early decision
+	// for a form it specifically recognizes - GroupConcat's implementation below does not attempt that
+	// general analysis and only recognizes one specific, already-fixed case.
+	Monotonic(ctx *sql.Context, buffer sql.Row, having sql.Expression) bool
+}
+
+var _ StreamingAggregation = (*GroupConcat)(nil)
+
+// CanStream implements the StreamingAggregation interface. GroupConcat can only stream when there's no
+// ORDER BY: with one, a row seen later could still sort ahead of rows already accumulated and change the
+// group_concat_max_len-truncated prefix, so no partial result is ever final until every row has arrived.
+func (g *GroupConcat) CanStream() bool {
+	return g.sf == nil
+}
+
+// Monotonic implements the StreamingAggregation interface.
+//
+// This does not actually perform HAVING pushdown: having is accepted to satisfy the interface but is never
+// inspected below. The only case recognized is buf.truncated - once set, Update is already discarding every
+// further row because the concatenation has exceeded group_concat_max_len, so the string Eval will produce
+// is completely fixed and having's truth value against it can't change no matter what having actually
+// checks, so it's always safe to report the group as decided at that point.
+//
+// Real HAVING pushdown would mean recognizing having as sargable against a quantity that only moves in one
+// direction while sf is nil (e.g. LENGTH(...) > n, ... LIKE 'prefix%' against the accumulated prefix), which
+// needs pattern-matching against the expression tree the GROUP BY executor builds having from. That
+// analysis isn't implemented, so outside the truncated case the honest answer is always "not decided".
+func (g *GroupConcat) Monotonic(ctx *sql.Context, buffer sql.Row, having sql.Expression) bool {
+	buf := buffer[0].(*groupConcatBuffer)
+	return buf.truncated
+}
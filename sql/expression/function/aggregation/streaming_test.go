@@ -0,0 +1,52 @@
+// Copyright 2021 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aggregation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dolthub/go-mysql-server/sql"
+	"github.com/dolthub/go-mysql-server/sql/expression"
+)
+
+func TestGroupConcatCanStream(t *testing.T) {
+	col := expression.NewGetField(0, sql.LongText, "a", true)
+
+	noOrder, err := NewGroupConcat("", nil, ",", []sql.Expression{col})
+	require.NoError(t, err)
+	require.True(t, noOrder.CanStream())
+
+	ordered, err := NewGroupConcat("", sql.SortFields{{Column: col, Order: sql.Ascending}}, ",", []sql.Expression{col})
+	require.NoError(t, err)
+	require.False(t, ordered.CanStream())
+}
+
+func TestGroupConcatMonotonicOnceTruncated(t *testing.T) {
+	ctx := sql.NewEmptyContext()
+	require.NoError(t, ctx.SetSessionVariable(ctx, "group_concat_max_len", int64(4)))
+	require.NoError(t, ctx.SetSessionVariable(ctx, "group_concat_mem_limit", defaultGroupConcatMemLimit))
+
+	col := expression.NewGetField(0, sql.LongText, "a", true)
+	gc, err := NewGroupConcat("", nil, ",", []sql.Expression{col})
+	require.NoError(t, err)
+
+	buf := gc.NewBuffer()
+	require.False(t, gc.Monotonic(ctx, buf, nil))
+
+	require.NoError(t, gc.Update(ctx, buf, sql.NewRow("abcdef")))
+	require.True(t, gc.Monotonic(ctx, buf, nil))
+}
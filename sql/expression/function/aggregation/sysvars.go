@@ -0,0 +1,36 @@
+// Copyright 2021 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aggregation
+
+import (
+	"math"
+
+	"github.com/dolthub/go-mysql-server/sql"
+)
+
+// init registers group_concat_mem_limit as a session system variable, so that SET group_concat_mem_limit =
+// N actually takes effect instead of every session silently falling back to defaultGroupConcatMemLimit.
+// getGroupConcatMemLimit reads it back the same way it reads the built-in group_concat_max_len.
+func init() {
+	sql.SystemVariables.AddSystemVariables([]sql.SystemVariable{
+		&sql.MysqlSystemVariable{
+			Name:    "group_concat_mem_limit",
+			Scope:   sql.GetMysqlScope(sql.SystemVariableScope_Session),
+			Dynamic: true,
+			Type:    sql.NewSystemIntType("group_concat_mem_limit", 1, math.MaxInt64, false),
+			Default: defaultGroupConcatMemLimit,
+		},
+	})
+}